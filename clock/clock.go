@@ -0,0 +1,49 @@
+// Package clock wraps the few bits of the time package Server relies on
+// behind an interface, so tests can drive the 60s backfill threshold and
+// the ticker intervals in milliseconds instead of waiting on a wall
+// clock. Modeled on the facebookgo/clock pattern.
+package clock
+
+import "time"
+
+// Ticker mirrors time.Ticker, but as an interface so MockClock can hand
+// out one it controls.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Clock is everywhere Server touches the wall clock: Now for
+// timestamps/thresholds, NewTicker for the discovery/reconcile loops,
+// Sleep for the startup delay, and After for the shutdown deadline.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// New returns the production Clock, backed directly by the time package.
+func New() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+
+func (r realTicker) Stop() { r.t.Stop() }
@@ -0,0 +1,106 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// MockClock is a Clock that only advances when Add is called, so tests
+// can fast-forward the 60s backfill threshold, the discovery/reconcile
+// tickers, or the circuit breaker's backoff without actually waiting.
+type MockClock struct {
+	mu sync.Mutex
+	now time.Time
+	waiters []*mockWaiter
+	tickers []*mockTicker
+}
+
+// NewMock returns a MockClock starting at the unix epoch.
+func NewMock() *MockClock {
+	return &MockClock{now: time.Unix(0, 0)}
+}
+
+func (m *MockClock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *MockClock) Sleep(d time.Duration) {
+	<-m.After(d)
+}
+
+func (m *MockClock) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	m.waiters = append(m.waiters, &mockWaiter{at: m.now.Add(d), ch: ch})
+	return ch
+}
+
+func (m *MockClock) NewTicker(d time.Duration) Ticker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := &mockTicker{clock: m, period: d, next: m.now.Add(d), ch: make(chan time.Time, 1)}
+	m.tickers = append(m.tickers, t)
+	return t
+}
+
+// Add advances the mock's time by d, firing every waiter and ticker
+// whose deadline falls at or before the new time.
+func (m *MockClock) Add(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = m.now.Add(d)
+
+	remaining := m.waiters[:0]
+	for _, w := range m.waiters {
+		if !m.now.Before(w.at) {
+			select {
+			case w.ch <- m.now:
+			default:
+			}
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	m.waiters = remaining
+
+	for _, t := range m.tickers {
+		for !m.now.Before(t.next) {
+			select {
+			case t.ch <- m.now:
+			default:
+			}
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+type mockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+type mockTicker struct {
+	clock *MockClock
+	period time.Duration
+	next time.Time
+	ch chan time.Time
+}
+
+func (t *mockTicker) C() <-chan time.Time { return t.ch }
+
+func (t *mockTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, other := range t.clock.tickers {
+		if other == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package metrics defines the Prometheus collectors Server reports through
+// /metrics. Counters and histograms are registered eagerly in init, so any
+// package can import metrics and record against them without Server having
+// to wire anything up; the gauges that depend on live state (queue depth,
+// open circuit breakers) are registered on demand via the QueueDepth and
+// CircuitBreakersOpen helpers once Start has something to read from.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Failure kinds recorded against ImageBuildFailures. SVG rendering and GCS
+// upload both happen inside creators.PepeImageCreator.Create, which today
+// returns a single opaque error, so both collapse into FailureKindImageCreate
+// until that package exposes which stage failed.
+const (
+	FailureKindContractRead = "contract_read"
+	FailureKindImageCreate  = "image_create"
+
+	// FailureKindPanic covers a recovered panic from DNA parsing or
+	// ImageCreator.Create (e.g. a corrupt genotype) — the one failure
+	// kind that isn't a plain returned error.
+	FailureKindPanic = "panic"
+)
+
+var (
+	PepesDiscovered = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pepe_worker_pepes_discovered_total",
+		Help: "PepeIds enqueued for building, from logs or the discovery ticker.",
+	})
+
+	ImagesBuilt = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pepe_worker_images_built_total",
+		Help: "Images successfully built and pushed to storage.",
+	})
+
+	ImageBuildFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pepe_worker_image_build_failures_total",
+		Help: "Image build failures, by the stage that failed.",
+	}, []string{"kind"})
+
+	GetPepeLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "pepe_worker_get_pepe_duration_seconds",
+		Help: "Latency of the GetPepe contract call.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	ImageCreateLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "pepe_worker_image_create_duration_seconds",
+		Help: "Latency of DNA parsing and ImageCreator.Create.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		PepesDiscovered,
+		ImagesBuilt,
+		ImageBuildFailures,
+		GetPepeLatency,
+		ImageCreateLatency,
+	)
+}
+
+// QueueDepth registers a gauge that calls fn on every scrape, labeled by
+// queue (e.g. "high" or "low"). Server uses this for highPriority and
+// lowPriority, since a channel's current length is already cheap to read.
+func QueueDepth(queue string, fn func() float64) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pepe_worker_queue_depth",
+		Help: "Number of pepeIds currently queued, by priority.",
+		ConstLabels: prometheus.Labels{"queue": queue},
+	}, fn))
+}
+
+// CircuitBreakersOpen registers a gauge reporting, on every scrape, how many
+// pepeIds are currently backed off by the per-pepe circuit breaker.
+func CircuitBreakersOpen(fn func() float64) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pepe_worker_circuit_breakers_open",
+		Help: "Number of pepeIds currently backed off by the circuit breaker.",
+	}, fn))
+}
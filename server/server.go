@@ -5,8 +5,10 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 	"context"
 	"sync"
+	"strconv"
 	"cryptopepe.io/cryptopepe-worker/creators"
 	"cryptopepe.io/cryptopepe-worker/abi/sale"
 	"cryptopepe.io/cryptopepe-worker/abi/cozy"
@@ -15,75 +17,313 @@ import (
 	"cryptopepe.io/cryptopepe-worker/pepe"
 	"cryptopepe.io/cryptopepe-svg/builder/look"
 	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/event"
+	"golang.org/x/time/rate"
+	"cryptopepe.io/cryptopepe-worker/store"
+	"cryptopepe.io/cryptopepe-worker/clock"
+	"cryptopepe.io/cryptopepe-worker/metrics"
+	"net/http"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+const (
+	// number of workers draining the priority queues, overridable via WORKER_POOL_SIZE
+	defaultWorkerPoolSize = 8
+
+	// number of shards the per-pepe build state is spread across, so
+	// workers building unrelated pepes never contend on the same lock
+	buildShardCount = 64
+
+	// per-pepe circuit breaker backoff bounds
+	breakerInitialBackoff = time.Second
+	breakerMaxBackoff     = 10 * time.Minute
+
+	// backoff bounds for retrying a failed (or failed-to-resubscribe) log
+	// subscription in watchLogs
+	logSubscribeInitialBackoff = time.Second
+	logSubscribeMaxBackoff     = time.Minute
+
+	// ceiling on contract reads/sec shared by every worker
+	contractCallsPerSecond = 20
+
+	// how long Start waits, after a shutdown signal, for in-flight work
+	// to finish before exiting non-zero anyway
+	shutdownGracePeriod = 15 * time.Second
+
+	// where /metrics, /healthz and /readyz are served, overridable via METRICS_ADDR
+	defaultMetricsAddr = ":9090"
+)
 
 type ContractSessions struct {
 	PepeCallSession *token.TokenCallerSession
 	SaleAuctionCallSession *sale.SaleCallerSession
 	CozyAuctionCallSession *cozy.CozyCallerSession
+
+	// Filterer used to subscribe to Birth/Transfer logs on the token contract.
+	PepeFilterer *token.TokenFilterer
 }
 
-type ImageBuildStatus struct {
-	// how many backfill updates to do
-	UpdatesLeft uint8
-	LastUpdateTime int64
-	Success bool
+// ImageBuildStatus is the in-memory view of a pepe's build state; it's
+// the same shape persisted by the Store, so loading and saving never
+// need to convert between two parallel structs.
+type ImageBuildStatus = store.BuildStatus
+
+// buildShard is one slice of the sharded imageBuilds map: its own lock
+// plus the subset of pepeIds that hash to it, so two workers building
+// different pepes never block on each other.
+type buildShard struct {
+	mu sync.Mutex
+	builds map[uint64]ImageBuildStatus
+
+	// pepeIds currently inside processPepe, so discoverNewPepes/
+	// backfillPepeImages never enqueue an id a worker is already building.
+	// Deliberately not part of ImageBuildStatus: it's per-process in-flight
+	// state, not something that should ever be persisted.
+	building map[uint64]bool
 }
 
 type ImageHandlerProps struct {
 
 	ImageCreator *creators.PepeImageCreator
 
-	// Map of all images being successfully processed or not
-	imageBuilds map[uint64]ImageBuildStatus
+	// Sharded map of all images being successfully processed or not.
+	buildShards [buildShardCount]*buildShard
+
+	// Shared across workers so a burst of backfill work can't hammer the
+	// node; getPepe blocks on this before every contract read.
+	contractLimiter *rate.Limiter
+
+	// Persists build state across restarts. See the store package for
+	// the available drivers; nil disables persistence entirely (every
+	// restart re-checks every pepe, as before).
+	Store store.BuildStore
+
+	// Wall-clock access, swapped for a clock.MockClock in tests. Start
+	// defaults this to clock.New() if left nil.
+	Clock clock.Clock
+}
+
+func (props *ImageHandlerProps) shardFor(pepeId uint64) *buildShard {
+	return props.buildShards[pepeId % buildShardCount]
+}
+
+// pepeCallOpts starts from whatever CallOpts are already configured on
+// PepeCallSession (Pending, From, BlockNumber) and threads ctx through
+// them, so a cancellable call doesn't silently drop those in favor of a
+// bare CallOpts.
+func (srv *Server) pepeCallOpts(ctx context.Context) *bind.CallOpts {
+	opts := srv.ContractSessions.PepeCallSession.CallOpts
+	opts.Context = ctx
+	return &opts
+}
+
+// LogSubscriptionProps holds the state needed to keep a live subscription on
+// the token contract's Birth/Transfer logs, and to resume it after a drop.
+type LogSubscriptionProps struct {
+
+	// Newly discovered pepeIds (from Birth/Transfer logs, or the fast
+	// discovery ticker) so a large backfill can never starve them.
+	highPriority chan uint64
+
+	// Backfill work enqueued by the slow ticker's UpdatesLeft scan. Only
+	// drained once highPriority is empty.
+	lowPriority chan uint64
+
+	// last block we're known to have received logs up to, used to resume
+	// a subscription after a reconnect without missing anything.
+	lastBlock uint64
+
+	// highest pepeId the fast discovery ticker has already enqueued, so
+	// it only ever looks at ids above this on its next tick.
+	lastSeenPepeId uint64
+}
+
+// ReadinessProps tracks the two preconditions /readyz waits on: the initial
+// TotalSupply call Start makes before doing anything else, and a live log
+// subscription. Orchestrators shouldn't route traffic (or, for a worker,
+// count on discovery actually happening) until both are true.
+type ReadinessProps struct {
+	mu sync.Mutex
+	totalSupplyOK bool
+	logsConnected bool
+}
+
+func (r *ReadinessProps) setTotalSupplyOK(ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.totalSupplyOK = ok
+}
+
+func (r *ReadinessProps) setLogsConnected(ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logsConnected = ok
+}
 
-	// Only build one image at a time
-	imageMutex sync.Mutex
+func (r *ReadinessProps) ready() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.totalSupplyOK && r.logsConnected
 }
 
 type Server struct {
 
 	ContractSessions
 	ImageHandlerProps
+	LogSubscriptionProps
+	ReadinessProps
 }
 
+func workerPoolSize() int {
+	if raw := os.Getenv("WORKER_POOL_SIZE"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultWorkerPoolSize
+}
+
+func metricsAddr() string {
+	if raw := os.Getenv("METRICS_ADDR"); raw != "" {
+		return raw
+	}
+	return defaultMetricsAddr
+}
+
+// registerMetricsOnce guards the QueueDepth/CircuitBreakersOpen gauges
+// below: they're registered on the default Prometheus registry, which
+// panics on a duplicate registration, and Start can run more than once in
+// the same process (e.g. a test against a clock.MockClock).
+var registerMetricsOnce sync.Once
+
 func (srv *Server) Start() {
 
-	srv.imageBuilds = make(map[uint64]ImageBuildStatus)
+	if srv.Clock == nil {
+		srv.Clock = clock.New()
+	}
+
+	for i := range srv.buildShards {
+		srv.buildShards[i] = &buildShard{
+			builds: make(map[uint64]ImageBuildStatus),
+			building: make(map[uint64]bool),
+		}
+	}
+	srv.contractLimiter = rate.NewLimiter(rate.Limit(contractCallsPerSecond), contractCallsPerSecond)
+	srv.highPriority = make(chan uint64, 256)
+	srv.lowPriority = make(chan uint64, 256)
+
+	registerMetricsOnce.Do(func() {
+		metrics.QueueDepth("high", func() float64 { return float64(len(srv.highPriority)) })
+		metrics.QueueDepth("low", func() float64 { return float64(len(srv.lowPriority)) })
+		metrics.CircuitBreakersOpen(func() float64 { return float64(srv.countOpenBreakers()) })
+	})
+
+	go srv.startMetricsServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Load whatever was persisted last run, so a restart doesn't have to
+	// re-check every pepe against GCS from scratch.
+	srv.loadBuildState()
 
 	// hack; sleep for a while, just to be sure of network for supply call
-	time.Sleep(2 * time.Second)
+	srv.Clock.Sleep(2 * time.Second)
 
-	pepeCount, err := srv.ContractSessions.PepeCallSession.TotalSupply()
+	pepeCount, err := srv.ContractSessions.PepeCallSession.Contract.TotalSupply(srv.pepeCallOpts(ctx))
 	if err != nil {
 		panic("Could not get Pepe count for initialization")
 	}
 	count := pepeCount.Uint64()
 	for	pepeId := uint64(1); pepeId < count; pepeId++ {
-		if _, ok := srv.imageBuilds[pepeId]; !ok {
-			srv.imageBuilds[pepeId] = ImageBuildStatus{
-				UpdatesLeft:    0,
-				Success:        false,
-				LastUpdateTime: 0,
+		srv.ensureTracked(pepeId)
+	}
+	// the fast discovery ticker only looks above this on its next tick
+	if count > 0 {
+		srv.lastSeenPepeId = count - 1
+	}
+	srv.setTotalSupplyOK(true)
+
+	// wg covers every goroutine Start spawns, so shutdown can wait on all
+	// of them at once instead of assuming which one is slow.
+	var wg sync.WaitGroup
+
+	// Bounded pool draining highPriority and lowPriority and building
+	// images; this is where the actual work happens, whether the id came
+	// from a log, the discovery ticker, or the backfill ticker.
+	// highPriority always wins, so a deep backfill queue can never starve
+	// a freshly-minted pepe. Each worker watches ctx directly rather than
+	// relying on the channels being closed, so shutdown can't deadlock on
+	// a worker that's currently inside processPepe/getPepe/handleImage.
+	for i := 0; i < workerPoolSize(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case pepeId := <-srv.highPriority:
+					srv.processPepe(ctx, pepeId)
+					continue
+				default:
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case pepeId := <-srv.highPriority:
+					srv.processPepe(ctx, pepeId)
+				case pepeId := <-srv.lowPriority:
+					srv.processPepe(ctx, pepeId)
+				}
 			}
-		}
+		}()
 	}
 
-	
-	srv.imageMutex = sync.Mutex{}
+	// Subscribes to Birth/Transfer logs so newly minted or transferred
+	// pepes are picked up within a block or two, instead of waiting on
+	// the discovery ticker below.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		srv.watchLogs(ctx)
+	}()
+
+	// Fast: only checks TotalSupply and enqueues ids above the last-seen
+	// max, so a freshly-minted pepe surfaces within seconds even if the
+	// log subscription has a gap.
+	discover := srv.Clock.NewTicker(5 * time.Second)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer discover.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-discover.C():
+				srv.discoverNewPepes(ctx)
+			}
+		}
+	}()
 
-	stopCh := make(chan string)
-	checkPepeImages := time.NewTicker(10 * time.Second)
-	// Run our task in a goroutine so that it doesn't block.
+	// Slow: scans existing entries for ones due a backfill re-render.
+	// Deliberately separate from discovery so a deep backfill queue can
+	// never delay it.
+	backfill := srv.Clock.NewTicker(60 * time.Second)
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
+		defer backfill.Stop()
 		for {
 			select {
-			case <-stopCh:
+			case <-ctx.Done():
 				fmt.Println("Stopping main service")
 				return
-			case <-checkPepeImages.C:
-				srv.checkPepeImages()
+			case <-backfill.C():
+				srv.backfillPepeImages(ctx)
 			}
 		}
 	}()
@@ -91,104 +331,572 @@ func (srv *Server) Start() {
 	log.Println("Started worker!")
 
 	c := make(chan os.Signal, 1)
-	// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C)
-	// SIGKILL, SIGQUIT or SIGTERM (Ctrl+/) will not be caught.
-	signal.Notify(c, os.Interrupt)
+	// Accept graceful shutdowns on SIGINT (Ctrl+C), SIGTERM (how
+	// orchestrators ask a container to stop), and SIGQUIT.
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
-	// Block until we receive our signal.
+	// Block until we receive one of those signals.
 	<-c
+	log.Println("shutting down")
 
-	// Create a deadline to wait for.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second * 15)
-	defer cancel()
-	// Doesn't block if no work, but will otherwise wait
-	// until the timeout deadline.
-	stopCh <- "stop!"
-	<- ctx.Done()
+	// Cancelling ctx is our "done" signal: every goroutine above selects
+	// on ctx.Done(), so this single call reaches all of them instead of
+	// a single stopCh send that only one goroutine could ever receive.
+	cancel()
 
-	log.Println("shutting down")
-	os.Exit(0)
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
 
+	select {
+	case <-stopped:
+		log.Println("all workers stopped cleanly")
+		os.Exit(0)
+	case <-srv.Clock.After(shutdownGracePeriod):
+		log.Println("shutdown grace period elapsed with work still in flight")
+		os.Exit(1)
+	}
 }
 
-func (srv *Server) checkPepeImages() error {
+// startMetricsServer serves /metrics, /healthz and /readyz. It runs for the
+// life of the process rather than being covered by wg/ctx: an orchestrator
+// polling /healthz during shutdown should keep seeing a response for as
+// long as the process is up, including the shutdownGracePeriod drain.
+func (srv *Server) startMetricsServer() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	// Liveness: the process is up and serving HTTP at all.
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Readiness: the initial TotalSupply call has succeeded and the log
+	// subscription is connected, so discovery is actually keeping up.
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !srv.ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	addr := metricsAddr()
+	log.Printf("serving metrics on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("metrics server stopped: %v", err)
+	}
+}
 
-	fmt.Println("Checking pepe images")
+// countOpenBreakers scans every shard for pepeIds currently backed off by
+// the circuit breaker, for the pepe_worker_circuit_breakers_open gauge.
+func (srv *Server) countOpenBreakers() int {
+	open := 0
+	now := srv.Clock.Now().Unix()
+	for _, shard := range srv.buildShards {
+		shard.mu.Lock()
+		for _, status := range shard.builds {
+			if status.ConsecutiveFailures > 0 && now < status.NextRetryAt {
+				open++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return open
+}
 
-	defer srv.imageMutex.Unlock()
-	srv.imageMutex.Lock()
+// watchLogs subscribes to Birth and Transfer events on the token contract
+// and enqueues the affected pepeId as soon as a log arrives. On a
+// subscription error (e.g. the websocket dropped, or the initial dial
+// fails) it retries with exponential backoff until a subscription holds,
+// resuming from lastBlock so the only gaps left to fill are closed by the
+// discovery ticker (discoverNewPepes).
+func (srv *Server) watchLogs(ctx context.Context) {
+
+	birthCh := make(chan *token.TokenBirth, 64)
+	transferCh := make(chan *token.TokenTransfer, 64)
+
+	// retryC fires when it's time to attempt subscribeLogs again; nil
+	// (blocks forever) whenever both subs are currently live.
+	var retryC <-chan time.Time
+	subscribeFailures := 0
+
+	scheduleRetry := func() {
+		subscribeFailures++
+		retryC = srv.Clock.After(logSubscribeBackoff(subscribeFailures))
+	}
 
-	pepeCount, err := srv.ContractSessions.PepeCallSession.TotalSupply()
+	birthSub, transferSub, err := srv.subscribeLogs(ctx, birthCh, transferCh)
+	srv.setLogsConnected(err == nil)
 	if err != nil {
-		return nil
+		fmt.Println("could not start log subscription, retrying with backoff:", err)
+		scheduleRetry()
 	}
-	count := pepeCount.Uint64()
-	fmt.Printf("Processing pepes for image building, total count: %d\n", count)
 
-	now := time.Now().Unix()
-	// wait a minute, then backfill again.
-	timeThreshold := now - 60
-	// ignore pepe 0, start from 1
-	errCount := 0
-	for	pepeId := uint64(1); pepeId < count; pepeId++ {
-		if errCount > 5 {
-			fmt.Println("Too many errors, something is wrong, stopping update")
-		}
-		if _, ok := srv.imageBuilds[pepeId]; !ok {
-			srv.imageBuilds[pepeId] = ImageBuildStatus{
-				UpdatesLeft: 10,
-				Success: false,
-				LastUpdateTime: 0,
+	for {
+		select {
+		case <-ctx.Done():
+			if birthSub != nil {
+				birthSub.Unsubscribe()
 			}
-		}
-		// If it is already created, but we have yet to do some backfills, and it is time to do so,
-		//  then mark it as non-success again, to force a backfill.
-		if srv.imageBuilds[pepeId].Success &&
-			srv.imageBuilds[pepeId].UpdatesLeft > 0 &&
-			srv.imageBuilds[pepeId].LastUpdateTime < timeThreshold {
+			if transferSub != nil {
+				transferSub.Unsubscribe()
+			}
+			return
 
-			srv.imageBuilds[pepeId] = ImageBuildStatus{
-				UpdatesLeft: srv.imageBuilds[pepeId].UpdatesLeft - 1,
-				Success: false,
-				LastUpdateTime: srv.imageBuilds[pepeId].LastUpdateTime,
+		case <-retryC:
+			birthSub, transferSub, err = srv.subscribeLogs(ctx, birthCh, transferCh)
+			srv.setLogsConnected(err == nil)
+			if err != nil {
+				fmt.Println("log subscription retry failed, retrying with backoff:", err)
+				scheduleRetry()
+				continue
+			}
+			subscribeFailures = 0
+			retryC = nil
+
+		case ev := <-birthCh:
+			srv.lastBlock = ev.Raw.BlockNumber
+			srv.persistLastBlock()
+			metrics.PepesDiscovered.Inc()
+			pepeId := ev.PepeId.Uint64()
+			// Same as discoverNewPepes: without this, a pepeId whose first
+			// build comes from this subscription never gets an UpdatesLeft,
+			// and ensureTracked's already-tracked guard means it can never
+			// be repaired later.
+			srv.ensureTracked(pepeId)
+			select {
+			case srv.highPriority <- pepeId:
+			case <-ctx.Done():
+			}
+
+		case ev := <-transferCh:
+			srv.lastBlock = ev.Raw.BlockNumber
+			srv.persistLastBlock()
+			pepeId := ev.TokenId.Uint64()
+			srv.ensureTracked(pepeId)
+			select {
+			case srv.highPriority <- pepeId:
+			case <-ctx.Done():
 			}
-		}
-		if !srv.imageBuilds[pepeId].Success {
-			fmt.Printf("Building images for pepe %d\n", pepeId)
 
-			parsedPepe, err := srv.getPepe(big.NewInt(int64(pepeId)))
+		case err := <-errChan(birthSub):
+			fmt.Println("birth log subscription dropped, resubscribing:", err)
+			srv.setLogsConnected(false)
+			// transferSub is still live; subscribeLogs hands back a fresh
+			// pair, so drop this one first or its underlying subscription
+			// goroutine leaks.
+			if transferSub != nil {
+				transferSub.Unsubscribe()
+			}
+			birthSub, transferSub, err = srv.subscribeLogs(ctx, birthCh, transferCh)
+			srv.setLogsConnected(err == nil)
+			if err != nil {
+				fmt.Println("resubscribe failed, retrying with backoff:", err)
+				scheduleRetry()
+				continue
+			}
+			subscribeFailures = 0
+			retryC = nil
+
+		case err := <-errChan(transferSub):
+			fmt.Println("transfer log subscription dropped, resubscribing:", err)
+			srv.setLogsConnected(false)
+			// birthSub is still live; same reasoning as above, mirrored.
+			if birthSub != nil {
+				birthSub.Unsubscribe()
+			}
+			birthSub, transferSub, err = srv.subscribeLogs(ctx, birthCh, transferCh)
+			srv.setLogsConnected(err == nil)
 			if err != nil {
-				fmt.Println(err)
-				errCount++
+				fmt.Println("resubscribe failed, retrying with backoff:", err)
+				scheduleRetry()
 				continue
 			}
-			dna := pepe.PepeDNA(parsedPepe.Genotype)
-			parsedLook := (&dna).ParsePepeDNA()
+			subscribeFailures = 0
+			retryC = nil
+		}
+	}
+}
 
-			fmt.Printf("Succesfully retrieved and parsed data for pepe %d\n", pepeId)
+// errChan returns sub.Err(), or nil if sub itself is nil (receiving from a
+// nil channel just blocks forever, which is what we want while there's no
+// subscription to watch).
+func errChan(sub event.Subscription) <-chan error {
+	if sub == nil {
+		return nil
+	}
+	return sub.Err()
+}
+
+// subscribeLogs (re)establishes the Birth/Transfer watches, resuming from
+// lastBlock so a reconnect doesn't lose any logs in between.
+func (srv *Server) subscribeLogs(ctx context.Context, birthCh chan *token.TokenBirth, transferCh chan *token.TokenTransfer) (event.Subscription, event.Subscription, error) {
 
-			if err := srv.handleImage(pepeId, parsedPepe, parsedLook); err != nil {
-				fmt.Println(err)
-				errCount++
+	opts := &bind.WatchOpts{Context: ctx}
+	if srv.lastBlock > 0 {
+		opts.Start = &srv.lastBlock
+	}
+
+	birthSub, err := srv.PepeFilterer.WatchBirth(opts, birthCh, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transferSub, err := srv.PepeFilterer.WatchTransfer(opts, transferCh, nil, nil, nil)
+	if err != nil {
+		birthSub.Unsubscribe()
+		return nil, nil, err
+	}
+
+	return birthSub, transferSub, nil
+}
+
+// ensureTracked makes sure pepeId has a tracked build status, so it shows
+// up in the backfill scan and survives a restart, even before it's ever
+// been built.
+func (srv *Server) ensureTracked(pepeId uint64) {
+	shard := srv.shardFor(pepeId)
+
+	shard.mu.Lock()
+	if _, ok := shard.builds[pepeId]; ok {
+		shard.mu.Unlock()
+		return
+	}
+	status := ImageBuildStatus{
+		UpdatesLeft: 10,
+		Success: false,
+		LastUpdateTime: 0,
+	}
+	shard.builds[pepeId] = status
+	shard.mu.Unlock()
+
+	// The disk write doesn't need the shard held: it only protects the
+	// in-memory map, and every other pepeId on this shard would otherwise
+	// queue up behind one Store.Put.
+	srv.persistBuildStatus(pepeId, status)
+}
+
+// discoverNewPepes is the fast ticker: it only calls TotalSupply and
+// enqueues whatever is above lastSeenPepeId into highPriority, so a
+// freshly-minted pepe surfaces in seconds even if the log subscription
+// has a gap. It never looks at existing entries; backfillPepeImages
+// below owns that.
+func (srv *Server) discoverNewPepes(ctx context.Context) {
+
+	pepeCount, err := srv.ContractSessions.PepeCallSession.Contract.TotalSupply(srv.pepeCallOpts(ctx))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	count := pepeCount.Uint64()
+	if count == 0 {
+		return
+	}
+	maxId := count - 1
+	if maxId <= srv.lastSeenPepeId {
+		return
+	}
+
+	fmt.Printf("Discovered pepes up to %d\n", maxId)
+
+	for pepeId := srv.lastSeenPepeId + 1; pepeId <= maxId; pepeId++ {
+		srv.ensureTracked(pepeId)
+		metrics.PepesDiscovered.Inc()
+		select {
+		case srv.highPriority <- pepeId:
+		case <-ctx.Done():
+			return
+		}
+	}
+	srv.lastSeenPepeId = maxId
+}
+
+// backfillPepeImages is the slow ticker: it scans every tracked pepe for
+// one whose LastUpdateTime is stale and UpdatesLeft > 0, or that never
+// built successfully, and enqueues it into lowPriority. It never touches
+// the contract, so a deep backfill can't compete with discoverNewPepes
+// for RPC calls.
+func (srv *Server) backfillPepeImages(ctx context.Context) {
+
+	fmt.Println("Backfilling pepe images")
+
+	now := srv.Clock.Now().Unix()
+	// wait a minute, then backfill again.
+	timeThreshold := now - 60
+
+	for _, shard := range srv.buildShards {
+		var due []uint64
+
+		shard.mu.Lock()
+		for pepeId, status := range shard.builds {
+			// Already being built by a worker; re-enqueuing it here would
+			// let two workers build the same id at once.
+			if shard.building[pepeId] {
 				continue
 			}
+			// If it is already created, but we have yet to do some backfills, and it is time to do so,
+			//  then mark it as non-success again, to force a backfill.
+			if status.Success &&
+				status.UpdatesLeft > 0 &&
+				status.LastUpdateTime < timeThreshold {
+
+				status = ImageBuildStatus{
+					UpdatesLeft: status.UpdatesLeft - 1,
+					Success: false,
+					LastUpdateTime: status.LastUpdateTime,
+				}
+				shard.builds[pepeId] = status
+				srv.persistBuildStatus(pepeId, status)
+			}
+			if !status.Success {
+				due = append(due, pepeId)
+			}
+		}
+		shard.mu.Unlock()
 
-			// Set it to true, do not rebuild next iteration.
-			srv.imageBuilds[pepeId] = ImageBuildStatus{
-				UpdatesLeft: srv.imageBuilds[pepeId].UpdatesLeft,
-				Success: true,
-				LastUpdateTime: now,
+		for _, pepeId := range due {
+			select {
+			case srv.lowPriority <- pepeId:
+			case <-ctx.Done():
+				return
 			}
-			fmt.Printf("Succesfully created images and pushed them to GC storage for pepe %d\n", pepeId)
 		}
 	}
+}
 
-	return nil
+// loadBuildState populates the shards from the Store, so a restart
+// resumes from whatever was last persisted instead of re-checking every
+// pepe against GCS. A nil Store leaves the shards empty, so every pepe is
+// re-checked; a read error part-way through keeps whatever entries were
+// loaded before it.
+func (srv *Server) loadBuildState() {
+	if srv.Store == nil {
+		return
+	}
+
+	err := srv.Store.Iterate(func(pepeId uint64, status ImageBuildStatus) error {
+		shard := srv.shardFor(pepeId)
+		shard.mu.Lock()
+		shard.builds[pepeId] = status
+		shard.mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		fmt.Println("could not load persisted build state, starting fresh:", err)
+	}
+
+	meta, err := srv.Store.GetMeta()
+	if err != nil {
+		fmt.Println("could not load persisted subscription metadata:", err)
+		return
+	}
+	srv.lastBlock = meta.LastBlock
 }
 
-func (srv *Server) getPepe(pepeId *big.Int) (*pepe.Pepe, error) {
+// persistBuildStatus writes a single pepe's status to the Store. It's a
+// no-op when no Store is configured.
+func (srv *Server) persistBuildStatus(pepeId uint64, status ImageBuildStatus) {
+	if srv.Store == nil {
+		return
+	}
+	if err := srv.Store.Put(pepeId, status); err != nil {
+		fmt.Printf("could not persist build status for pepe %d: %v\n", pepeId, err)
+	}
+}
 
-	rawPepe, err := srv.ContractSessions.PepeCallSession.GetPepe(pepeId)
+// persistLastBlock records how far the log subscription has gotten, so a
+// restart can resume from here instead of replaying from genesis.
+func (srv *Server) persistLastBlock() {
+	if srv.Store == nil {
+		return
+	}
+	meta, err := srv.Store.GetMeta()
+	if err != nil {
+		fmt.Println("could not read subscription metadata:", err)
+		return
+	}
+	meta.SchemaVersion = store.CurrentSchemaVersion
+	meta.LastBlock = srv.lastBlock
+	if err := srv.Store.PutMeta(meta); err != nil {
+		fmt.Println("could not persist subscription metadata:", err)
+	}
+}
+
+// exponentialBackoff doubles from initial up to max, failures times.
+// Shared by breakerBackoff (per-pepe circuit breaker) and
+// logSubscribeBackoff (retrying a dropped log subscription).
+func exponentialBackoff(failures int, initial, max time.Duration) time.Duration {
+	backoff := initial
+	for i := 0; i < failures; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	return backoff
+}
+
+// breakerBackoff returns how long to wait before retrying a pepe that has
+// failed consecutiveFailures times in a row, doubling from
+// breakerInitialBackoff up to breakerMaxBackoff.
+func breakerBackoff(consecutiveFailures int) time.Duration {
+	return exponentialBackoff(consecutiveFailures, breakerInitialBackoff, breakerMaxBackoff)
+}
+
+// logSubscribeBackoff returns how long watchLogs should wait before the
+// next subscribeLogs retry, after failures consecutive failed attempts.
+func logSubscribeBackoff(failures int) time.Duration {
+	return exponentialBackoff(failures, logSubscribeInitialBackoff, logSubscribeMaxBackoff)
+}
+
+// processPepe builds (or rebuilds) the image for a single pepeId. It is
+// called by every worker regardless of whether pepeId came off
+// highPriority or lowPriority. A per-pepe circuit breaker keeps a
+// permanently broken id (e.g. corrupt genotype) from being retried on
+// every tick; healthy ids are never blocked by it since breaker state is
+// kept per pepe and the shard lock is only held briefly. shard.building marks pepeId in flight for the duration
+// of this call, so a worker never builds an id a different worker is
+// already building (e.g. one pulled off highPriority moments before
+// backfillPepeImages re-enqueued the same id from lowPriority).
+func (srv *Server) processPepe(ctx context.Context, pepeId uint64) {
+
+	shard := srv.shardFor(pepeId)
+
+	shard.mu.Lock()
+	status := shard.builds[pepeId]
+	if status.ConsecutiveFailures > 0 && srv.Clock.Now().Unix() < status.NextRetryAt {
+		shard.mu.Unlock()
+		return
+	}
+	if shard.building[pepeId] {
+		shard.mu.Unlock()
+		return
+	}
+	shard.building[pepeId] = true
+	shard.mu.Unlock()
+
+	defer func() {
+		shard.mu.Lock()
+		delete(shard.building, pepeId)
+		shard.mu.Unlock()
+	}()
+
+	fmt.Printf("Building images for pepe %d\n", pepeId)
+
+	if err := srv.contractLimiter.Wait(ctx); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	getPepeStart := srv.Clock.Now()
+	parsedPepe, err := srv.getPepe(ctx, big.NewInt(int64(pepeId)))
+	metrics.GetPepeLatency.Observe(srv.Clock.Now().Sub(getPepeStart).Seconds())
+	if err != nil {
+		srv.recordFailure(pepeId, metrics.FailureKindContractRead, err)
+		return
+	}
+	dna := pepe.PepeDNA(parsedPepe.Genotype)
+
+	createStart := srv.Clock.Now()
+	err = srv.buildAndHandleImage(ctx, pepeId, parsedPepe, &dna)
+	metrics.ImageCreateLatency.Observe(srv.Clock.Now().Sub(createStart).Seconds())
+	if err != nil {
+		kind := metrics.FailureKindImageCreate
+		if _, ok := err.(*panicError); ok {
+			kind = metrics.FailureKindPanic
+		}
+		srv.recordFailure(pepeId, kind, err)
+		return
+	}
+
+	srv.recordSuccess(pepeId)
+	metrics.ImagesBuilt.Inc()
+	fmt.Printf("Succesfully created images and pushed them to GC storage for pepe %d\n", pepeId)
+}
+
+// panicError wraps a recovered panic from buildAndHandleImage, so
+// processPepe can tell it apart from an ordinary returned error and
+// record it against FailureKindPanic instead of FailureKindImageCreate.
+type panicError struct {
+	pepeId    uint64
+	recovered interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic building pepe %d: %v", e.pepeId, e.recovered)
+}
+
+// withPanicRecovered runs fn and converts a panic into a *panicError
+// tagged with pepeId, instead of letting it unwind past processPepe and
+// take the whole worker process down with it. Split out from
+// buildAndHandleImage so the recover/convert behavior can be unit
+// tested without the external pepe/creators types fn closes over.
+func withPanicRecovered(pepeId uint64, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &panicError{pepeId: pepeId, recovered: r}
+		}
+	}()
+	return fn()
+}
+
+// buildAndHandleImage parses pepeId's DNA and builds/uploads its image,
+// recovering from a panic in either step. ParsePepeDNA and
+// ImageCreator.Create are both handed raw on-chain bytes, and this
+// codebase has no recover() anywhere else: without one here, a single
+// corrupt genotype — exactly the "permanently broken pepe" the circuit
+// breaker exists for — would crash the whole worker process instead of
+// just opening that pepe's breaker.
+func (srv *Server) buildAndHandleImage(ctx context.Context, pepeId uint64, parsedPepe *pepe.Pepe, dna *pepe.PepeDNA) error {
+	return withPanicRecovered(pepeId, func() error {
+		parsedLook := dna.ParsePepeDNA()
+		fmt.Printf("Succesfully retrieved and parsed data for pepe %d\n", pepeId)
+		return srv.handleImage(ctx, pepeId, parsedPepe, parsedLook)
+	})
+}
+
+// recordFailure opens the circuit for pepeId a little wider: the next
+// retry is pushed out by the exponential backoff.
+func (srv *Server) recordFailure(pepeId uint64, kind string, err error) {
+	fmt.Println(err)
+	metrics.ImageBuildFailures.WithLabelValues(kind).Inc()
+
+	shard := srv.shardFor(pepeId)
+
+	shard.mu.Lock()
+	status := shard.builds[pepeId]
+	status.ConsecutiveFailures++
+	status.NextRetryAt = srv.Clock.Now().Add(breakerBackoff(status.ConsecutiveFailures)).Unix()
+	shard.builds[pepeId] = status
+	shard.mu.Unlock()
+
+	// See ensureTracked: the Store.Put shouldn't hold up every other
+	// pepeId on this shard.
+	srv.persistBuildStatus(pepeId, status)
+}
+
+// recordSuccess closes the circuit for pepeId and marks it built.
+func (srv *Server) recordSuccess(pepeId uint64) {
+	shard := srv.shardFor(pepeId)
+
+	shard.mu.Lock()
+	status := shard.builds[pepeId]
+	status.Success = true
+	status.LastUpdateTime = srv.Clock.Now().Unix()
+	status.ConsecutiveFailures = 0
+	status.NextRetryAt = 0
+	shard.builds[pepeId] = status
+	shard.mu.Unlock()
+
+	srv.persistBuildStatus(pepeId, status)
+}
+
+func (srv *Server) getPepe(ctx context.Context, pepeId *big.Int) (*pepe.Pepe, error) {
+
+	rawPepe, err := srv.ContractSessions.PepeCallSession.Contract.GetPepe(srv.pepeCallOpts(ctx), pepeId)
 	if err != nil {
 		return nil, err
 	}
@@ -198,7 +906,13 @@ func (srv *Server) getPepe(pepeId *big.Int) (*pepe.Pepe, error) {
 	return &parsedPepe, nil
 }
 
-func (srv *Server) handleImage(pepeId uint64, parsedPepe *pepe.Pepe, parsedLook *look.PepeLook) error {
+// handleImage takes ctx even though ImageCreator.Create isn't
+// cancellable today, so it aborts promptly once that's threaded through
+// too rather than needing another signature change down the line.
+func (srv *Server) handleImage(ctx context.Context, pepeId uint64, parsedPepe *pepe.Pepe, parsedLook *look.PepeLook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	err := srv.ImageCreator.Create(pepeId, parsedPepe, parsedLook, true)
 	if err != nil {
@@ -206,4 +920,4 @@ func (srv *Server) handleImage(pepeId uint64, parsedPepe *pepe.Pepe, parsedLook
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cryptopepe.io/cryptopepe-worker/clock"
+)
+
+func TestBreakerBackoff(t *testing.T) {
+	cases := []struct {
+		failures int
+		want time.Duration
+	}{
+		{0, breakerInitialBackoff},
+		{1, 2 * breakerInitialBackoff},
+		{2, 4 * breakerInitialBackoff},
+		{3, 8 * breakerInitialBackoff},
+		{20, breakerMaxBackoff},
+	}
+	for _, c := range cases {
+		if got := breakerBackoff(c.failures); got != c.want {
+			t.Errorf("breakerBackoff(%d) = %v, want %v", c.failures, got, c.want)
+		}
+	}
+}
+
+func TestLogSubscribeBackoff(t *testing.T) {
+	cases := []struct {
+		failures int
+		want time.Duration
+	}{
+		{0, logSubscribeInitialBackoff},
+		{1, 2 * logSubscribeInitialBackoff},
+		{2, 4 * logSubscribeInitialBackoff},
+		{20, logSubscribeMaxBackoff},
+	}
+	for _, c := range cases {
+		if got := logSubscribeBackoff(c.failures); got != c.want {
+			t.Errorf("logSubscribeBackoff(%d) = %v, want %v", c.failures, got, c.want)
+		}
+	}
+}
+
+func TestWithPanicRecoveredConvertsPanicToError(t *testing.T) {
+	err := withPanicRecovered(42, func() error {
+		panic("corrupt genotype")
+	})
+
+	var pe *panicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got error %v (%T), want *panicError", err, err)
+	}
+	if pe.pepeId != 42 {
+		t.Fatalf("got pepeId %d, want 42", pe.pepeId)
+	}
+}
+
+func TestWithPanicRecoveredPassesThroughError(t *testing.T) {
+	want := errors.New("contract read failed")
+
+	got := withPanicRecovered(42, func() error {
+		return want
+	})
+
+	if got != want {
+		t.Fatalf("got error %v, want %v", got, want)
+	}
+}
+
+func newTestServer(mock *clock.MockClock) *Server {
+	srv := &Server{}
+	srv.Clock = mock
+	for i := range srv.buildShards {
+		srv.buildShards[i] = &buildShard{
+			builds: make(map[uint64]ImageBuildStatus),
+			building: make(map[uint64]bool),
+		}
+	}
+	srv.lowPriority = make(chan uint64, 8)
+	return srv
+}
+
+func TestBackfillPepeImagesEnqueuesStaleSuccesses(t *testing.T) {
+	mock := clock.NewMock()
+	srv := newTestServer(mock)
+
+	shard := srv.shardFor(42)
+	shard.builds[42] = ImageBuildStatus{
+		UpdatesLeft: 3,
+		Success: true,
+		LastUpdateTime: mock.Now().Unix(),
+	}
+
+	// backfillPepeImages only considers a success stale once 60s have
+	// passed since LastUpdateTime.
+	mock.Add(61 * time.Second)
+
+	srv.backfillPepeImages(context.Background())
+
+	select {
+	case pepeId := <-srv.lowPriority:
+		if pepeId != 42 {
+			t.Fatalf("got pepeId %d, want 42", pepeId)
+		}
+	default:
+		t.Fatal("expected pepe 42 to be enqueued for backfill")
+	}
+
+	shard.mu.Lock()
+	status := shard.builds[42]
+	shard.mu.Unlock()
+	if status.Success {
+		t.Fatal("expected Success to be cleared so the worker re-renders it")
+	}
+	if status.UpdatesLeft != 2 {
+		t.Fatalf("got UpdatesLeft %d, want 2", status.UpdatesLeft)
+	}
+}
+
+func TestBackfillPepeImagesSkipsInFlight(t *testing.T) {
+	mock := clock.NewMock()
+	srv := newTestServer(mock)
+
+	shard := srv.shardFor(7)
+	shard.builds[7] = ImageBuildStatus{Success: false}
+	shard.building[7] = true
+
+	srv.backfillPepeImages(context.Background())
+
+	select {
+	case pepeId := <-srv.lowPriority:
+		t.Fatalf("pepe %d should not be enqueued while already building", pepeId)
+	default:
+	}
+}
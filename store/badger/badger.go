@@ -0,0 +1,146 @@
+package badger
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"cryptopepe.io/cryptopepe-worker/store"
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+var metaKey = []byte("meta")
+
+// pepeKey namespaces per-pepe keys with a "p" prefix so Iterate's prefix
+// scan never picks up metaKey.
+func pepeKey(pepeId uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = 'p'
+	binary.BigEndian.PutUint64(key[1:], pepeId)
+	return key
+}
+
+// Store is a BadgerDB-backed store.BuildStore, useful once the build
+// state grows large enough that BoltDB's single mmap'd file becomes
+// unwieldy.
+type Store struct {
+	db *badger.DB
+}
+
+// Open opens (creating if needed) a BadgerDB directory at path and
+// migrates the schema if it's out of date.
+func Open(path string) (*Store, error) {
+	db, err := badger.Open(badger.DefaultOptions(path))
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate upgrades an older on-disk schema version in place; see
+// bolt.Store.migrate for the rationale, this mirrors it.
+func (s *Store) migrate() error {
+	meta, err := s.GetMeta()
+	if err != nil {
+		return err
+	}
+	switch meta.SchemaVersion {
+	case store.CurrentSchemaVersion:
+		return nil
+	case 0:
+		meta.SchemaVersion = store.CurrentSchemaVersion
+		return s.PutMeta(meta)
+	default:
+		return fmt.Errorf("build store schema version %d is newer than this binary supports (%d)", meta.SchemaVersion, store.CurrentSchemaVersion)
+	}
+}
+
+func (s *Store) Get(pepeId uint64) (store.BuildStatus, bool, error) {
+	var status store.BuildStatus
+	found := false
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(pepeKey(pepeId))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &status)
+		})
+	})
+	return status, found, err
+}
+
+func (s *Store) Put(pepeId uint64, status store.BuildStatus) error {
+	raw, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(pepeKey(pepeId), raw)
+	})
+}
+
+func (s *Store) Iterate(fn func(pepeId uint64, status store.BuildStatus) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte{'p'}
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			var status store.BuildStatus
+			err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &status)
+			})
+			if err != nil {
+				return err
+			}
+			pepeId := binary.BigEndian.Uint64(item.Key()[1:])
+			if err := fn(pepeId, status); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) GetMeta() (store.Meta, error) {
+	var meta store.Meta
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(metaKey)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &meta)
+		})
+	})
+	return meta, err
+}
+
+func (s *Store) PutMeta(meta store.Meta) error {
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(metaKey, raw)
+	})
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,139 @@
+package bolt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"cryptopepe.io/cryptopepe-worker/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	buildsBucket = []byte("builds")
+	metaBucket   = []byte("meta")
+	metaKey      = []byte("meta")
+)
+
+// Store is a BoltDB-backed store.BuildStore: a single mmap'd file, no
+// external service to run. Server does not open it on its own; it is
+// used only when set as Server.Store.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if needed) a BoltDB file at path, ensures its
+// buckets exist, and migrates the schema if it's out of date.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(buildsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate upgrades an older on-disk schema version in place. There has
+// only ever been version 1 so far; a version 2 migration would read the
+// old encoding under these same buckets and rewrite it before bumping
+// SchemaVersion.
+func (s *Store) migrate() error {
+	meta, err := s.GetMeta()
+	if err != nil {
+		return err
+	}
+	switch meta.SchemaVersion {
+	case store.CurrentSchemaVersion:
+		return nil
+	case 0:
+		meta.SchemaVersion = store.CurrentSchemaVersion
+		return s.PutMeta(meta)
+	default:
+		return fmt.Errorf("build store schema version %d is newer than this binary supports (%d)", meta.SchemaVersion, store.CurrentSchemaVersion)
+	}
+}
+
+func pepeKey(pepeId uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, pepeId)
+	return key
+}
+
+func (s *Store) Get(pepeId uint64) (store.BuildStatus, bool, error) {
+	var status store.BuildStatus
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(buildsBucket).Get(pepeKey(pepeId))
+		if raw == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(raw, &status)
+	})
+	return status, found, err
+}
+
+func (s *Store) Put(pepeId uint64, status store.BuildStatus) error {
+	raw, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(buildsBucket).Put(pepeKey(pepeId), raw)
+	})
+}
+
+func (s *Store) Iterate(fn func(pepeId uint64, status store.BuildStatus) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(buildsBucket).ForEach(func(k, v []byte) error {
+			var status store.BuildStatus
+			if err := json.Unmarshal(v, &status); err != nil {
+				return err
+			}
+			return fn(binary.BigEndian.Uint64(k), status)
+		})
+	})
+}
+
+func (s *Store) GetMeta() (store.Meta, error) {
+	var meta store.Meta
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(metaBucket).Get(metaKey)
+		if raw == nil {
+			return nil
+		}
+		return json.Unmarshal(raw, &meta)
+	})
+	return meta, err
+}
+
+func (s *Store) PutMeta(meta store.Meta) error {
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(metaKey, raw)
+	})
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
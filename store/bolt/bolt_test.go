@@ -0,0 +1,72 @@
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+
+	"cryptopepe.io/cryptopepe-worker/store"
+)
+
+func TestPutGetIterateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "builds.db")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	want := store.BuildStatus{
+		UpdatesLeft: 3,
+		LastUpdateTime: 1234,
+		Success: true,
+	}
+	if err := s.Put(42, want); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, found, err := s.Get(42)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !found {
+		t.Fatal("Get: pepe 42 not found")
+	}
+	if got != want {
+		t.Fatalf("Get: got %+v, want %+v", got, want)
+	}
+
+	if _, found, err := s.Get(7); err != nil || found {
+		t.Fatalf("Get: pepe 7 should not be found, got found=%v err=%v", found, err)
+	}
+
+	seen := map[uint64]store.BuildStatus{}
+	if err := s.Iterate(func(pepeId uint64, status store.BuildStatus) error {
+		seen[pepeId] = status
+		return nil
+	}); err != nil {
+		t.Fatalf("Iterate: %v", err)
+	}
+	if len(seen) != 1 || seen[42] != want {
+		t.Fatalf("Iterate: got %+v, want {42: %+v}", seen, want)
+	}
+}
+
+func TestOpenRejectsNewerSchemaVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "builds.db")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := s.PutMeta(store.Meta{SchemaVersion: store.CurrentSchemaVersion + 1}); err != nil {
+		t.Fatalf("PutMeta: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Fatal("Open: expected an error reopening a store with a newer schema version, got nil")
+	}
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cryptopepe.io/cryptopepe-worker/store"
+	redis "github.com/go-redis/redis/v8"
+)
+
+const (
+	metaKey = "pepe-worker:meta"
+	keyPrefix = "pepe-worker:build:"
+)
+
+func pepeKey(pepeId uint64) string {
+	return keyPrefix + strconv.FormatUint(pepeId, 10)
+}
+
+// Store is a Redis-backed store.BuildStore, for deployments that already
+// run Redis and would rather share build state across worker instances
+// than manage an embedded DB file per instance.
+type Store struct {
+	client *redis.Client
+	ctx context.Context
+}
+
+// Open connects to the Redis instance at addr and migrates the schema if
+// it's out of date.
+func Open(addr string) (*Store, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+	s := &Store{client: client, ctx: ctx}
+	if err := s.migrate(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate upgrades an older schema version in place; see
+// bolt.Store.migrate for the rationale, this mirrors it.
+func (s *Store) migrate() error {
+	meta, err := s.GetMeta()
+	if err != nil {
+		return err
+	}
+	switch meta.SchemaVersion {
+	case store.CurrentSchemaVersion:
+		return nil
+	case 0:
+		meta.SchemaVersion = store.CurrentSchemaVersion
+		return s.PutMeta(meta)
+	default:
+		return fmt.Errorf("build store schema version %d is newer than this binary supports (%d)", meta.SchemaVersion, store.CurrentSchemaVersion)
+	}
+}
+
+func (s *Store) Get(pepeId uint64) (store.BuildStatus, bool, error) {
+	var status store.BuildStatus
+	raw, err := s.client.Get(s.ctx, pepeKey(pepeId)).Bytes()
+	if err == redis.Nil {
+		return status, false, nil
+	}
+	if err != nil {
+		return status, false, err
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return status, false, err
+	}
+	return status, true, nil
+}
+
+func (s *Store) Put(pepeId uint64, status store.BuildStatus) error {
+	raw, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(s.ctx, pepeKey(pepeId), raw, 0).Err()
+}
+
+func (s *Store) Iterate(fn func(pepeId uint64, status store.BuildStatus) error) error {
+	iter := s.client.Scan(s.ctx, 0, keyPrefix+"*", 0).Iterator()
+	for iter.Next(s.ctx) {
+		key := iter.Val()
+		raw, err := s.client.Get(s.ctx, key).Bytes()
+		if err != nil {
+			return err
+		}
+		var status store.BuildStatus
+		if err := json.Unmarshal(raw, &status); err != nil {
+			return err
+		}
+		pepeId, err := strconv.ParseUint(strings.TrimPrefix(key, keyPrefix), 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := fn(pepeId, status); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+func (s *Store) GetMeta() (store.Meta, error) {
+	var meta store.Meta
+	raw, err := s.client.Get(s.ctx, metaKey).Bytes()
+	if err == redis.Nil {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(raw, &meta)
+	return meta, err
+}
+
+func (s *Store) PutMeta(meta store.Meta) error {
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(s.ctx, metaKey, raw, 0).Err()
+}
+
+func (s *Store) Close() error {
+	return s.client.Close()
+}
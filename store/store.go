@@ -0,0 +1,45 @@
+package store
+
+// CurrentSchemaVersion is bumped whenever the on-disk encoding of
+// BuildStatus or Meta changes in a way that existing drivers need to
+// migrate. Drivers check this on Open and upgrade in place.
+const CurrentSchemaVersion = 1
+
+// BuildStatus is the persisted form of a pepe's image build state, keyed
+// by pepeId in the store.
+type BuildStatus struct {
+	// how many backfill updates to do
+	UpdatesLeft uint8
+	LastUpdateTime int64
+	Success bool
+
+	// circuit breaker: consecutive failures since the last success, and
+	// the earliest time (unix seconds) we're allowed to retry
+	ConsecutiveFailures int
+	NextRetryAt int64
+}
+
+// Meta tracks store-wide bookkeeping that isn't per-pepe: the schema
+// version the data was written with, and the last block the log
+// subscription had processed up to, so a restart can resume a
+// subscription instead of replaying from genesis.
+type Meta struct {
+	SchemaVersion int
+	LastBlock uint64
+}
+
+// BuildStore is a pluggable persistence layer for per-pepe build state,
+// so a restart doesn't have to re-check every pepe against GCS. Drivers
+// live in their own subpackages: store/bolt, store/badger, and
+// store/redis. Server does not open any of them itself; with no Store set
+// it persists nothing.
+type BuildStore interface {
+	Get(pepeId uint64) (BuildStatus, bool, error)
+	Put(pepeId uint64, status BuildStatus) error
+	Iterate(fn func(pepeId uint64, status BuildStatus) error) error
+
+	GetMeta() (Meta, error)
+	PutMeta(meta Meta) error
+
+	Close() error
+}